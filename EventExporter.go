@@ -2,101 +2,140 @@ package main
 
 import (
 	"encoding/xml"
-	"github.com/prometheus/client_golang/prometheus"
-	"io/ioutil"
-	"log"
+	"fmt"
+	"log/syslog"
 	"net/http"
 	"os"
-	"strings"
 	"sync"
-	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EventSink is where parsed modem events are written to, in addition to
+// being counted as metrics.
+type EventSink string
+
+const (
+	EventSinkNone   EventSink = "none"
+	EventSinkFile   EventSink = "file"
+	EventSinkStdout EventSink = "stdout"
+	EventSinkSyslog EventSink = "syslog"
 )
 
+// ParseEventSink validates a sink name from netgear_cm_exporter.yml.
+func ParseEventSink(sink string) (EventSink, error) {
+	switch EventSink(sink) {
+	case EventSinkNone, EventSinkFile, EventSinkStdout, EventSinkSyslog:
+		return EventSink(sink), nil
+	default:
+		return "", errors.Errorf("unknown event sink %q", sink)
+	}
+}
+
+// eventSeenCapacity bounds how many recently-seen event keys are kept in
+// memory, so a burst of events sharing a timestamp isn't dropped while still
+// not growing unbounded across the life of the process.
+const eventSeenCapacity = 512
+
+// EventExporter scrapes a modem's event log page and exposes the events it
+// finds as Prometheus counters, optionally mirroring them to a sink.
 type EventExporter struct {
+	device               string
 	url, authHeaderValue string
+	driver               ModemDriver
+	logger               log.Logger
+	httpClient           *http.Client
+
+	sink         EventSink
+	filePath     string
+	syslogWriter *syslog.Writer
 
 	mu sync.Mutex
 
 	// Exporter metrics.
 	totalEventScrapes prometheus.Counter
 	scrapeEventErrors prometheus.Counter
+	eventTotal        *prometheus.CounterVec
 
-	eventTime        *prometheus.Desc
-	eventPriority    *prometheus.Desc
-	eventDescription *prometheus.Desc
+	seen *eventSeenSet
+}
 
-	eventTimeStamp time.Time
+// NewEventExporterFactory looks up the registered ModemDriver for modemType
+// and returns an EventExporter that scrapes addr with it.
+func NewEventExporterFactory(device, addr, username, password, modemType string, sink EventSink, path string, logger log.Logger, httpConfig HTTPClientConfig) (*EventExporter, error) {
+	driver, err := driverByName(modemType)
+	if err != nil {
+		return nil, err
+	}
+	return NewEventExporter(device, addr, username, password, driver, sink, path, logger, httpConfig)
 }
 
-// NewExporter returns an instance of Exporter configured with the modem's
-// address, admin username and password.
-func NewEventExporterFactory(addr, username, password string) *EventExporter {
-	var (
-		dsLabelNames = []string{"time", "priority", "description"}
-	)
+// NewEventExporter returns an instance of EventExporter configured with the
+// modem's address, admin username and password, scraped using driver, and
+// the sink new events should be mirrored to.
+func NewEventExporter(device, addr, username, password string, driver ModemDriver, sink EventSink, path string, logger log.Logger, httpConfig HTTPClientConfig) (*EventExporter, error) {
+	httpClient, err := httpClientFromConfig(httpConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	return &EventExporter{
-		// Modem access details.
-		url:             "http://" + addr + "/EventLog.asp",
+	e := &EventExporter{
+		device:          device,
+		url:             httpConfig.Scheme + "://" + addr + driver.EventURL(),
 		authHeaderValue: "Basic " + basicAuth(username, password),
-
-		// Collection metrics.
+		driver:          driver,
+		logger:          log.With(logger, "device", device),
+		httpClient:      httpClient,
+		sink:            sink,
+		filePath:        path,
+		seen:            newEventSeenSet(eventSeenCapacity),
+
+		// Collection metrics. Each EventExporter is scoped to a single
+		// device, so the device label is constant rather than a vector
+		// dimension, same as StatusExporter's scrape counters.
 		totalEventScrapes: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,
-			Name:      "status_event_scrapes_total",
-			Help:      "Total number of scrapes of the modem event page.",
+			Namespace:   namespace,
+			Name:        "status_event_scrapes_total",
+			Help:        "Total number of scrapes of the modem event page.",
+			ConstLabels: prometheus.Labels{"device": device},
 		}),
 		scrapeEventErrors: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,
-			Name:      "status_event_scrape_errors_total",
-			Help:      "Total number of failed scrapes of the modem event page.",
+			Namespace:   namespace,
+			Name:        "status_event_scrape_errors_total",
+			Help:        "Total number of failed scrapes of the modem event page.",
+			ConstLabels: prometheus.Labels{"device": device},
 		}),
 
-		// Events.
-		eventTime: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "event", "time"),
-			"Time of the Event.",
-			dsLabelNames, nil,
-		),
-		eventPriority: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "event", "priority"),
-			"Priority of the Event.",
-			dsLabelNames, nil,
-		),
-		eventDescription: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "event", "description"),
-			"Description of the Event.",
-			dsLabelNames, nil,
-		),
+		// Events, by severity level.
+		eventTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "event_total",
+			Help:      "Total number of modem events observed, by severity level.",
+		}, []string{"device", "level"}),
 	}
+
+	if sink == EventSinkSyslog {
+		writer, err := syslog.New(syslog.LOG_INFO, "netgear_cm_exporter")
+		if err != nil {
+			return nil, errors.Wrap(err, "connecting to syslog")
+		}
+		e.syslogWriter = writer
+	}
+
+	return e, nil
 }
 
 // Describe returns Prometheus metric descriptions for the exporter metrics.
 func (e *EventExporter) Describe(ch chan<- *prometheus.Desc) {
-	// Exporter metrics.
 	ch <- e.totalEventScrapes.Desc()
 	ch <- e.scrapeEventErrors.Desc()
-	// Event Data.
-	ch <- e.eventTime
-	ch <- e.eventPriority
-	ch <- e.eventDescription
-}
-
-func parseXMLTable(value string, a string, b string) string {
-	// Get substring between two strings.
-	posFirst := strings.Index(value, a)
-	if posFirst == -1 {
-		return ""
-	}
-	posLast := strings.Index(value, b)
-	if posLast == -1 {
-		return ""
-	}
-	posLastAdjusted := posLast + len(b)
-
-	return value[posFirst:posLastAdjusted]
+	e.eventTotal.Describe(ch)
 }
 
+// EventRow is a single <tr> of the modem's docsDevEventTable.
 type EventRow struct {
 	XMLName xml.Name `xml:"tr"`
 
@@ -109,98 +148,149 @@ type EventRow struct {
 	EventText      string `xml:"docsDevEvText"`
 }
 
-type EventTable struct {
-	XMLName xml.Name `xml:"docsDevEventTable"`
+// eventKey identifies an event row for deduplication. EventFirstTime alone
+// isn't unique: the modem reports "Time Not Established" for some events,
+// and bursts of unrelated events can share the same timestamp.
+type eventKey struct {
+	index     string
+	firstTime string
+	id        int
+	text      string
+}
 
-	EventRow []EventRow `xml:"tr"`
+// eventSeenSet remembers the most recently seen event keys so repeat scrapes
+// don't double-count events, bounded to avoid growing without limit.
+type eventSeenSet struct {
+	mu       sync.Mutex
+	keys     map[eventKey]struct{}
+	order    []eventKey
+	capacity int
 }
 
-// Collect runs our scrape loop returning each Prometheus metric.
-func (e *EventExporter) Collect(ch chan<- prometheus.Metric) {
+func newEventSeenSet(capacity int) *eventSeenSet {
+	return &eventSeenSet{
+		keys:     make(map[eventKey]struct{}, capacity),
+		capacity: capacity,
+	}
+}
 
-	path := "tmp/cm-event.log"
+// seenOrAdd reports whether key has already been seen, recording it if not.
+func (s *eventSeenSet) seenOrAdd(key eventKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	e.totalEventScrapes.Inc()
+	if _, ok := s.keys[key]; ok {
+		return true
+	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	s.keys[key] = struct{}{}
+	s.order = append(s.order, key)
+	if len(s.order) > s.capacity {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		delete(s.keys, oldest)
+	}
 
-	req, err := http.NewRequest("GET", e.url, nil)
+	return false
+}
 
-	if err != nil {
-		log.Println("Error setting request auth header")
-		log.Println(err)
-		return
+// Collect runs our scrape loop, returning each Prometheus metric and
+// reporting whether the scrape succeeded.
+func (e *EventExporter) Collect(ch chan<- prometheus.Metric) bool {
+	e.totalEventScrapes.Inc()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	success := true
+	if err := e.scrape(); err != nil {
+		level.Error(e.logger).Log("msg", "event scrape failed", "url", e.url, "err", err)
+		e.scrapeEventErrors.Inc()
+		success = false
 	}
 
-	req.Header.Add("Authorization", e.authHeaderValue)
+	e.totalEventScrapes.Collect(ch)
+	e.scrapeEventErrors.Collect(ch)
+	e.eventTotal.Collect(ch)
 
-	resp, err := client.Do(req)
+	return success
+}
 
+func (e *EventExporter) scrape() error {
+	req, err := http.NewRequest("GET", e.url, nil)
 	if err != nil {
-		log.Println("Error Calling Server for Events.")
-		log.Println(err)
-		return
+		return errors.Wrap(err, "building event request")
 	}
+	req.Header.Add("Authorization", e.authHeaderValue)
 
-	body, err := ioutil.ReadAll(resp.Body)
-
+	resp, err := e.httpClient.Do(req)
 	if err != nil {
-		log.Println(err)
-		return
+		return errors.Wrap(err, "requesting event page")
 	}
+	defer resp.Body.Close()
 
-	xmlData := parseXMLTable(string(body), "<docsDevEventTable>", "</docsDevEventTable>")
-
-	eventTable := EventTable{}
-	if err := xml.Unmarshal([]byte(xmlData), &eventTable); err != nil {
-		panic(err)
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("event page returned %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
-//	fmt.Printf("%+v", eventTable)
 
-	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	level.Debug(e.logger).Log("msg", "fetched event page", "url", e.url, "status_code", resp.StatusCode)
+
+	rows, err := e.driver.ParseEvents(resp.Body)
 	if err != nil {
-		log.Println("Error opening event log destination file : ")
-		log.Println(err)
+		return errors.Wrap(err, "parsing event page")
 	}
-	defer file.Close()
-
-	tne := "Time Not Established"
-
-	for i := 0; i < len(eventTable.EventRow); i++ {
-
-		row := eventTable.EventRow[i]
+	for _, row := range rows {
+		e.handleRow(row)
+	}
+	return nil
+}
 
-		evt := row.EventFirstTime
-		var  eventLogTime  time.Time
+func (e *EventExporter) handleRow(row EventRow) {
+	key := eventKey{index: row.EventIndex, firstTime: row.EventFirstTime, id: row.EventId, text: row.EventText}
+	if e.seen.seenOrAdd(key) {
+		return
+	}
 
-		if evt != tne {
-			// Get the Timestamp from the line
-			eventLogTimeFormat := "2006-01-02, 15:04:05"
-			eventLogTime, err = time.Parse(eventLogTimeFormat, evt)
-			if (err != nil) {
-				log.Println("Error while formatting Event Log Date : ")
-				log.Println(err)
-			}
-		} else {
-			eventLogTime = e.eventTimeStamp
-		}
+	level.Debug(e.logger).Log("msg", "parsed event row", "index", row.EventIndex, "level", row.EventLevel, "id", row.EventId)
 
-		if e.eventTimeStamp.After( eventLogTime ) {
-			continue // Skip ones we have already written
-		} else
-		{
-			formattedEventLog := "[" + row.EventFirstTime + "] " + row.EventLevel + " - " + row.EventText + "\n"
-			file.WriteString(formattedEventLog)
-			e.eventTimeStamp = eventLogTime
-		}
+	e.eventTotal.WithLabelValues(e.device, row.EventLevel).Inc()
+	e.writeSink(row)
+}
 
+func (e *EventExporter) writeSink(row EventRow) {
+	line := fmt.Sprintf("[%s] %s - %s\n", row.EventFirstTime, row.EventLevel, row.EventText)
+
+	switch e.sink {
+	case EventSinkFile:
+		e.appendToFile(line)
+	case EventSinkStdout:
+		fmt.Print(line)
+	case EventSinkSyslog:
+		e.writeSyslog(row.EventLevel, line)
+	case EventSinkNone:
 	}
+}
 
-	file.Sync()
+func (e *EventExporter) appendToFile(line string) {
+	file, err := os.OpenFile(e.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		level.Error(e.logger).Log("msg", "failed to open event log destination file", "path", e.filePath, "err", err)
+		return
+	}
+	defer file.Close()
 
-	e.mu.Lock()
-	e.totalEventScrapes.Collect(ch)
-	e.scrapeEventErrors.Collect(ch)
-	e.mu.Unlock()
+	if _, err := file.WriteString(line); err != nil {
+		level.Error(e.logger).Log("msg", "failed to write event log destination file", "path", e.filePath, "err", err)
+	}
 }
 
+func (e *EventExporter) writeSyslog(eventLevel, line string) {
+	switch eventLevel {
+	case "Error", "Critical", "Emergency", "Alert":
+		e.syslogWriter.Err(line)
+	case "Warning":
+		e.syslogWriter.Warning(line)
+	default:
+		e.syslogWriter.Info(line)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestEventSeenSetSeenOrAdd(t *testing.T) {
+	t.Run("new key is reported unseen and remembered", func(t *testing.T) {
+		s := newEventSeenSet(4)
+		key := eventKey{index: "1", firstTime: "2020-01-01,00:00:00", id: 68000100, text: "No Ranging Response received"}
+
+		if seen := s.seenOrAdd(key); seen {
+			t.Fatal("seenOrAdd on a new key reported seen")
+		}
+		if seen := s.seenOrAdd(key); !seen {
+			t.Fatal("seenOrAdd on a repeated key reported unseen")
+		}
+	})
+
+	t.Run("Time Not Established rows are distinguished by id and text", func(t *testing.T) {
+		s := newEventSeenSet(4)
+		a := eventKey{index: "1", firstTime: "Time Not Established", id: 68000100, text: "No Ranging Response received"}
+		b := eventKey{index: "2", firstTime: "Time Not Established", id: 82000500, text: "Lost MDD Timeout"}
+
+		if seen := s.seenOrAdd(a); seen {
+			t.Fatal("seenOrAdd on a new key reported seen")
+		}
+		if seen := s.seenOrAdd(b); seen {
+			t.Fatal("a distinct event sharing the unestablished timestamp was reported as already seen")
+		}
+	})
+
+	t.Run("capacity evicts the oldest key", func(t *testing.T) {
+		s := newEventSeenSet(2)
+		first := eventKey{index: "1", id: 1}
+		second := eventKey{index: "2", id: 2}
+		third := eventKey{index: "3", id: 3}
+
+		s.seenOrAdd(first)
+		s.seenOrAdd(second)
+		s.seenOrAdd(third)
+
+		if seen := s.seenOrAdd(first); seen {
+			t.Fatal("first key should have been evicted once capacity was exceeded")
+		}
+		if seen := s.seenOrAdd(third); !seen {
+			t.Fatal("third key should still be remembered")
+		}
+	})
+}
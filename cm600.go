@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterModemDriver(newCM600Driver())
+}
+
+var (
+	cm600DsLabelNames = []string{"device", "channel", "lock_status", "modulation", "channel_id", "frequency"}
+	cm600UsLabelNames = []string{"device", "channel", "lock_status", "channel_type", "channel_id", "frequency"}
+)
+
+// cm600Driver is the ModemDriver for the Netgear CM600.
+type cm600Driver struct {
+	// Downstream metrics.
+	dsChannelSNR               *prometheus.Desc
+	dsChannelPower             *prometheus.Desc
+	dsChannelCorrectableErrs   *prometheus.Desc
+	dsChannelUncorrectableErrs *prometheus.Desc
+
+	// Upstream metrics.
+	usChannelPower      *prometheus.Desc
+	usChannelSymbolRate *prometheus.Desc
+}
+
+func newCM600Driver() *cm600Driver {
+	return &cm600Driver{
+		dsChannelSNR: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "downstream_channel", "snr_db"),
+			"Downstream channel signal to noise ratio in dB.",
+			cm600DsLabelNames, nil,
+		),
+		dsChannelPower: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "downstream_channel", "power_dbmv"),
+			"Downstream channel power in dBmV.",
+			cm600DsLabelNames, nil,
+		),
+		dsChannelCorrectableErrs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "downstream_channel", "correctable_errors_total"),
+			"Downstream channel correctable errors.",
+			cm600DsLabelNames, nil,
+		),
+		dsChannelUncorrectableErrs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "downstream_channel", "uncorrectable_errors_total"),
+			"Downstream channel uncorrectable errors.",
+			cm600DsLabelNames, nil,
+		),
+		usChannelPower: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "upstream_channel", "power_dbmv"),
+			"Upstream channel power in dBmV.",
+			cm600UsLabelNames, nil,
+		),
+		usChannelSymbolRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "upstream_channel", "symbol_rate"),
+			"Upstream channel symbol rate per second",
+			cm600UsLabelNames, nil,
+		),
+	}
+}
+
+func (d *cm600Driver) Name() string      { return "CM600" }
+func (d *cm600Driver) StatusURL() string { return "/DocsisStatus.asp" }
+func (d *cm600Driver) EventURL() string  { return "/EventLog.asp" }
+
+// Describe returns Prometheus metric descriptions for the driver's metrics.
+func (d *cm600Driver) Describe(ch chan<- *prometheus.Desc) {
+	ch <- d.dsChannelSNR
+	ch <- d.dsChannelPower
+	ch <- d.dsChannelCorrectableErrs
+	ch <- d.dsChannelUncorrectableErrs
+	ch <- d.usChannelPower
+	ch <- d.usChannelSymbolRate
+}
+
+// ParseStatus decodes the CM600's DocsisStatus.asp page into metrics.
+func (d *cm600Driver) ParseStatus(logger log.Logger, device string, body io.Reader, ch chan<- prometheus.Metric) error {
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return errors.Wrap(err, "parsing status page")
+	}
+
+	// Downstream table.
+	doc.Find("#dsTable tbody tr").Each(func(i int, row *goquery.Selection) {
+		if i == 0 {
+			return // header row
+		}
+		var (
+			channel    string
+			lockStatus string
+			modulation string
+			channelID  string
+			freqMHz    string
+			power      float64
+			snr        float64
+			corrErrs   float64
+			unCorrErrs float64
+		)
+		row.Find("td").Each(func(j int, col *goquery.Selection) {
+			text := strings.TrimSpace(col.Text())
+
+			switch j {
+			case 0:
+				channel = text
+			case 1:
+				lockStatus = text
+			case 2:
+				modulation = text
+			case 3:
+				channelID = text
+			case 4:
+				{
+					var freqHZ float64
+					fmt.Sscanf(text, "%f Hz", &freqHZ)
+					freqMHz = fmt.Sprintf("%0.2f MHz", freqHZ/1e6)
+				}
+			case 5:
+				fmt.Sscanf(text, "%f dBmV", &power)
+			case 6:
+				fmt.Sscanf(text, "%f dB", &snr)
+			case 7:
+				fmt.Sscanf(text, "%f", &corrErrs)
+			case 8:
+				fmt.Sscanf(text, "%f", &unCorrErrs)
+			}
+		})
+		labels := []string{device, channel, lockStatus, modulation, channelID, freqMHz}
+		level.Debug(logger).Log("msg", "parsed downstream channel", "device", device, "channel", channel, "snr_db", snr, "power_dbmv", power)
+
+		ch <- prometheus.MustNewConstMetric(d.dsChannelSNR, prometheus.GaugeValue, snr, labels...)
+		ch <- prometheus.MustNewConstMetric(d.dsChannelPower, prometheus.GaugeValue, power, labels...)
+		ch <- prometheus.MustNewConstMetric(d.dsChannelCorrectableErrs, prometheus.CounterValue, corrErrs, labels...)
+		ch <- prometheus.MustNewConstMetric(d.dsChannelUncorrectableErrs, prometheus.CounterValue, unCorrErrs, labels...)
+	})
+
+	// Upstream table.
+	doc.Find("#usTable tbody tr").Each(func(i int, row *goquery.Selection) {
+		if i == 0 {
+			return // header row
+		}
+		var (
+			channel     string
+			lockStatus  string
+			channelType string
+			channelID   string
+			symbolRate  float64
+			freqMHz     string
+			power       float64
+		)
+		row.Find("td").Each(func(j int, col *goquery.Selection) {
+			text := strings.TrimSpace(col.Text())
+			switch j {
+			case 0:
+				channel = text
+			case 1:
+				lockStatus = text
+			case 2:
+				channelType = text
+			case 3:
+				channelID = text
+			case 4:
+				{
+					fmt.Sscanf(text, "%f Ksym/sec", &symbolRate)
+					symbolRate = symbolRate * 1000 // convert to sym/sec
+				}
+			case 5:
+				{
+					var freqHZ float64
+					fmt.Sscanf(text, "%f Hz", &freqHZ)
+					freqMHz = fmt.Sprintf("%0.2f MHz", freqHZ/1e6)
+				}
+			case 6:
+				fmt.Sscanf(text, "%f dBmV", &power)
+			}
+		})
+		labels := []string{device, channel, lockStatus, channelType, channelID, freqMHz}
+		level.Debug(logger).Log("msg", "parsed upstream channel", "device", device, "channel", channel, "power_dbmv", power, "symbol_rate", symbolRate)
+
+		ch <- prometheus.MustNewConstMetric(d.usChannelPower, prometheus.GaugeValue, power, labels...)
+		ch <- prometheus.MustNewConstMetric(d.usChannelSymbolRate, prometheus.GaugeValue, symbolRate, labels...)
+	})
+
+	return nil
+}
+
+// ParseEvents decodes the CM600's EventLog.asp page into its event rows,
+// using the standard DOCSIS event MIB table shared by every model.
+func (d *cm600Driver) ParseEvents(body io.Reader) ([]EventRow, error) {
+	return decodeEventRows(body)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the root of netgear_cm_exporter.yml. It describes the
+// exporter's own HTTP server plus every modem it should scrape.
+type Config struct {
+	Telemetry  TelemetryConfig  `yaml:"telemetry"`
+	Events     EventsConfig     `yaml:"events"`
+	HTTPClient HTTPClientConfig `yaml:"http_client"`
+	Devices    []DeviceConfig   `yaml:"devices"`
+}
+
+// HTTPClientConfig configures the outbound HTTP client used to scrape each
+// modem's admin pages.
+type HTTPClientConfig struct {
+	Scheme             string `yaml:"scheme"`
+	TimeoutSeconds     int    `yaml:"timeout_seconds"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	CAFile             string `yaml:"ca_file"`
+}
+
+// EventsConfig controls where modem events are written to, in addition to
+// being counted as metrics. Sink defaults to "none" (metrics only).
+type EventsConfig struct {
+	Sink string `yaml:"sink"`
+	Path string `yaml:"path"`
+}
+
+// TelemetryConfig configures the exporter's own HTTP server.
+type TelemetryConfig struct {
+	ListenAddress string `yaml:"listen_address"`
+	MetricsPath   string `yaml:"metrics_path"`
+}
+
+// DeviceConfig describes a single modem to scrape.
+type DeviceConfig struct {
+	Name     string `yaml:"name"`
+	Address  string `yaml:"address"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Model    string `yaml:"model"`
+}
+
+// NewConfigFromFile reads and parses a netgear_cm_exporter.yml file,
+// filling in defaults for any telemetry settings left unset.
+func NewConfigFromFile(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading config file")
+	}
+
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, errors.Wrap(err, "parsing config file")
+	}
+
+	if config.Telemetry.ListenAddress == "" {
+		config.Telemetry.ListenAddress = ":9527"
+	}
+	if config.Telemetry.MetricsPath == "" {
+		config.Telemetry.MetricsPath = "/metrics"
+	}
+	if config.Events.Sink == "" {
+		config.Events.Sink = string(EventSinkNone)
+	}
+	if config.HTTPClient.Scheme == "" {
+		config.HTTPClient.Scheme = "http"
+	}
+	if config.HTTPClient.TimeoutSeconds == 0 {
+		config.HTTPClient.TimeoutSeconds = 30
+	}
+
+	if len(config.Devices) == 0 {
+		return nil, fmt.Errorf("no devices configured in %s", path)
+	}
+
+	return config, nil
+}
+
+// DeviceByName returns the configured device with the given name, used by
+// the /probe handler to resolve a scrape target.
+func (c *Config) DeviceByName(name string) (DeviceConfig, bool) {
+	for _, d := range c.Devices {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return DeviceConfig{}, false
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// deviceTarget pairs a configured device name with the exporters that know
+// how to scrape it.
+type deviceTarget struct {
+	name   string
+	status CMExporter
+	events CMExporter
+}
+
+// deviceCollector fans out a Prometheus scrape across every configured
+// modem, scraping each one concurrently and recording scrape metadata.
+type deviceCollector struct {
+	targets []*deviceTarget
+
+	scrapeCollectorDuration *prometheus.Desc
+	scrapeCollectorSuccess  *prometheus.Desc
+}
+
+// newDeviceCollector returns a deviceCollector that scrapes every target
+// whenever Prometheus collects it.
+func newDeviceCollector(targets []*deviceTarget) *deviceCollector {
+	return &deviceCollector{
+		targets: targets,
+
+		scrapeCollectorDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape_collector", "duration_seconds"),
+			"Duration of a collector scrape for one device.",
+			[]string{"device"}, nil,
+		),
+		scrapeCollectorSuccess: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape_collector", "success"),
+			"Whether a collector scrape for one device succeeded.",
+			[]string{"device"}, nil,
+		),
+	}
+}
+
+// Describe returns Prometheus metric descriptions for every target's metrics.
+func (d *deviceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- d.scrapeCollectorDuration
+	ch <- d.scrapeCollectorSuccess
+	for _, t := range d.targets {
+		t.status.Describe(ch)
+		t.events.Describe(ch)
+	}
+}
+
+// Collect scrapes every target in parallel, waiting for all of them to
+// finish before returning.
+func (d *deviceCollector) Collect(ch chan<- prometheus.Metric) {
+	var wg sync.WaitGroup
+	wg.Add(len(d.targets))
+
+	for _, t := range d.targets {
+		go func(t *deviceTarget) {
+			defer wg.Done()
+			d.collectTarget(ch, t)
+		}(t)
+	}
+
+	wg.Wait()
+}
+
+func (d *deviceCollector) collectTarget(ch chan<- prometheus.Metric, t *deviceTarget) {
+	start := time.Now()
+	statusOK := t.status.Collect(ch)
+	eventsOK := t.events.Collect(ch)
+	success := statusOK && eventsOK
+	duration := time.Since(start).Seconds()
+
+	ch <- prometheus.MustNewConstMetric(d.scrapeCollectorDuration, prometheus.GaugeValue, duration, t.name)
+	ch <- prometheus.MustNewConstMetric(d.scrapeCollectorSuccess, prometheus.GaugeValue, successValue(success), t.name)
+}
+
+// successValue converts a scrape's success into the 1/0 Prometheus expects
+// for a gauge.
+func successValue(success bool) float64 {
+	if success {
+		return 1
+	}
+	return 0
+}
+
+// newProbeHandler returns an http.HandlerFunc implementing the Prometheus
+// multi-target exporter pattern: /probe?target=<device name> scrapes only
+// the named device and serves its metrics on a throwaway registry.
+func newProbeHandler(config *Config, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+		if target == "" {
+			http.Error(w, "target parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		device, ok := config.DeviceByName(target)
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown target %q", target), http.StatusNotFound)
+			return
+		}
+
+		status, err := NewStatusExporterFactory(device.Name, device.Address, device.Username, device.Password, device.Model, logger, config.HTTPClient)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sink, err := ParseEventSink(config.Events.Sink)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		events, err := NewEventExporterFactory(device.Name, device.Address, device.Username, device.Password, device.Model, sink, config.Events.Path, logger, config.HTTPClient)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(newDeviceCollector([]*deviceTarget{{name: device.Name, status: status, events: events}}))
+
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	}
+}
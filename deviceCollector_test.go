@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestDeviceCollectorRegistersTwoDevices is a regression test: before each
+// exporter's counters carried a device ConstLabel, two StatusExporters (or
+// EventExporters) registered in the same collector produced identical
+// unlabeled metric descriptors, and Gather rejected the duplicate.
+func TestDeviceCollectorRegistersTwoDevices(t *testing.T) {
+	httpConfig := HTTPClientConfig{Scheme: "http"}
+	driver := newCM600Driver()
+	logger := log.NewNopLogger()
+
+	targets := make([]*deviceTarget, 0, 2)
+	for _, name := range []string{"modem-a", "modem-b"} {
+		status, err := NewStatusExporter(name, "192.0.2.1", "admin", "password", driver, logger, httpConfig)
+		if err != nil {
+			t.Fatalf("NewStatusExporter(%q): %v", name, err)
+		}
+		events, err := NewEventExporter(name, "192.0.2.1", "admin", "password", driver, EventSinkNone, "", logger, httpConfig)
+		if err != nil {
+			t.Fatalf("NewEventExporter(%q): %v", name, err)
+		}
+		targets = append(targets, &deviceTarget{name: name, status: &status, events: events})
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(newDeviceCollector(targets)); err != nil {
+		t.Fatalf("registering collector for two devices: %v", err)
+	}
+
+	if _, err := registry.Gather(); err != nil {
+		t.Fatalf("Gather with two devices registered: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ModemDriver knows how to scrape and parse one Netgear modem model's admin
+// pages into Prometheus metrics. Each model registers itself from its own
+// file's init() (see cm600.go, cm1000.go), so adding a new model never
+// requires touching this file or main.go.
+type ModemDriver interface {
+	// Name is the model identifier used in netgear_cm_exporter.yml, e.g. "CM600".
+	Name() string
+
+	// StatusURL is the path of the modem's DOCSIS status page.
+	StatusURL() string
+
+	// EventURL is the path of the modem's event log page.
+	EventURL() string
+
+	// Describe sends the driver's own metric descriptions down ch.
+	Describe(ch chan<- *prometheus.Desc)
+
+	// ParseStatus decodes a DOCSIS status page response body into metrics
+	// labeled with the given device name, logging per-row detail to logger
+	// at debug level.
+	ParseStatus(logger log.Logger, device string, body io.Reader, ch chan<- prometheus.Metric) error
+
+	// ParseEvents decodes an event log page response body into its
+	// individual event rows. Every Netgear cable modem reports events
+	// through the same standard DOCSIS event MIB table, so this has no
+	// per-model variation the way ParseStatus does; EventExporter owns
+	// deduplication, per-severity counting, and sink fan-out on top of the
+	// rows returned here.
+	ParseEvents(body io.Reader) ([]EventRow, error)
+}
+
+var modemDrivers = map[string]ModemDriver{}
+
+// RegisterModemDriver makes a ModemDriver available under its Name(). Driver
+// files call this from init() so new models are added by dropping in a new
+// file rather than editing a central factory.
+func RegisterModemDriver(driver ModemDriver) {
+	name := driver.Name()
+	if _, exists := modemDrivers[name]; exists {
+		panic("netgear_cm_exporter: modem driver already registered: " + name)
+	}
+	modemDrivers[name] = driver
+}
+
+// driverByName looks up a registered ModemDriver, returning an error for an
+// unknown model instead of silently falling back to a default.
+func driverByName(name string) (ModemDriver, error) {
+	driver, ok := modemDrivers[name]
+	if !ok {
+		return nil, errors.Errorf("unknown modem model %q", name)
+	}
+	return driver, nil
+}
+
+// decodeEventRows streams the standard DOCSIS event MIB table
+// (docsDevEventTable) out of an EventLog.asp page, decoding each <tr> as
+// it's encountered rather than slicing out a substring and unmarshalling
+// the whole table at once. Shared by every ModemDriver's ParseEvents, since
+// the table format doesn't vary by model.
+func decodeEventRows(body io.Reader) ([]EventRow, error) {
+	decoder := xml.NewDecoder(body)
+
+	if err := skipToStartElement(decoder, "docsDevEventTable"); err != nil {
+		return nil, errors.Wrap(err, "locating event table")
+	}
+
+	var rows []EventRow
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding event table")
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "tr" {
+				continue
+			}
+			var row EventRow
+			if err := decoder.DecodeElement(&row, &t); err != nil {
+				return nil, errors.Wrap(err, "decoding event row")
+			}
+			rows = append(rows, row)
+		case xml.EndElement:
+			if t.Name.Local == "docsDevEventTable" {
+				return rows, nil
+			}
+		}
+	}
+}
+
+// skipToStartElement advances decoder past tokens until it finds the start
+// of the named element.
+func skipToStartElement(decoder *xml.Decoder, name string) error {
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == name {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDecodeEventRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []EventRow
+		wantErr bool
+	}{
+		{
+			name: "multiple rows",
+			body: `<docsDevEventTable>
+				<tr>
+					<docsDevEvIndex>1</docsDevEvIndex>
+					<docsDevEvFirstTime>2020-01-01,00:00:00</docsDevEvFirstTime>
+					<docsDevEvLastTime>2020-01-01,00:00:00</docsDevEvLastTime>
+					<docsDevEvCounts>1</docsDevEvCounts>
+					<docsDevEvLevel>Warning</docsDevEvLevel>
+					<docsDevEvId>68000100</docsDevEvId>
+					<docsDevEvText>No Ranging Response received</docsDevEvText>
+				</tr>
+				<tr>
+					<docsDevEvIndex>2</docsDevEvIndex>
+					<docsDevEvFirstTime>Time Not Established</docsDevEvFirstTime>
+					<docsDevEvLastTime>Time Not Established</docsDevEvLastTime>
+					<docsDevEvCounts>3</docsDevEvCounts>
+					<docsDevEvLevel>Critical</docsDevEvLevel>
+					<docsDevEvId>82000500</docsDevEvId>
+					<docsDevEvText>Lost MDD Timeout</docsDevEvText>
+				</tr>
+			</docsDevEventTable>`,
+			want: []EventRow{
+				{
+					EventIndex:     "1",
+					EventFirstTime: "2020-01-01,00:00:00",
+					EventLastTime:  "2020-01-01,00:00:00",
+					EventCounts:    1,
+					EventLevel:     "Warning",
+					EventId:        68000100,
+					EventText:      "No Ranging Response received",
+				},
+				{
+					EventIndex:     "2",
+					EventFirstTime: "Time Not Established",
+					EventLastTime:  "Time Not Established",
+					EventCounts:    3,
+					EventLevel:     "Critical",
+					EventId:        82000500,
+					EventText:      "Lost MDD Timeout",
+				},
+			},
+		},
+		{
+			name: "no rows",
+			body: `<docsDevEventTable></docsDevEventTable>`,
+			want: nil,
+		},
+		{
+			name:    "missing table",
+			body:    `<html><body>no event table here</body></html>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decodeEventRows(strings.NewReader(tc.body))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeEventRows: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(EventRow{}, "XMLName")); diff != "" {
+				t.Errorf("decodeEventRows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
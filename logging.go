@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// newLogger builds a leveled logger in the given format, filtered to the
+// given level. Every collector receives this logger (or one derived from
+// it with log.With) so operators can filter scrape failures cleanly and run
+// with --log.level=debug to see per-row parsing detail.
+func newLogger(logLevel, logFormat string) (log.Logger, error) {
+	var logger log.Logger
+	switch logFormat {
+	case "json":
+		logger = log.NewJSONLogger(log.NewSyncWriter(os.Stderr))
+	case "logfmt", "":
+		logger = log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	default:
+		return nil, errors.Errorf("unknown log format %q", logFormat)
+	}
+	logger = log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
+
+	option, err := parseLogLevel(logLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	return level.NewFilter(logger, option), nil
+}
+
+func parseLogLevel(logLevel string) (level.Option, error) {
+	switch logLevel {
+	case "debug":
+		return level.AllowDebug(), nil
+	case "info", "":
+		return level.AllowInfo(), nil
+	case "warn":
+		return level.AllowWarn(), nil
+	case "error":
+		return level.AllowError(), nil
+	default:
+		return nil, errors.Errorf("unknown log level %q", logLevel)
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"encoding/base64"
 	"flag"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"sync"
 
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/exporter-toolkit/web"
 )
 
 const namespace = "netgear_cm"
@@ -23,25 +26,20 @@ var (
 	buildDate string
 )
 
-// Status Exporter represents an instance of the Netgear cable modem exporter.
+// StatusExporter scrapes a single modem's DOCSIS status page, delegating the
+// page-specific parsing to a ModemDriver.
 type StatusExporter struct {
+	device               string
 	url, authHeaderValue string
+	driver               ModemDriver
+	logger               log.Logger
+	httpClient           *http.Client
 
 	mu sync.Mutex
 
 	// Exporter metrics.
 	totalScrapes prometheus.Counter
 	scrapeErrors prometheus.Counter
-
-	// Downstream metrics.
-	dsChannelSNR               *prometheus.Desc
-	dsChannelPower             *prometheus.Desc
-	dsChannelCorrectableErrs   *prometheus.Desc
-	dsChannelUncorrectableErrs *prometheus.Desc
-
-	// Upstream metrics.
-	usChannelPower      *prometheus.Desc
-	usChannelSymbolRate *prometheus.Desc
 }
 
 // basicAuth returns the base64 encoding of the username and password
@@ -52,117 +50,187 @@ func basicAuth(username, password string) string {
 }
 
 type CMExporter interface {
-	Collect(ch chan<- prometheus.Metric)
+	// Collect runs the exporter's scrape, reporting each Prometheus metric
+	// on ch and returning whether the scrape succeeded.
+	Collect(ch chan<- prometheus.Metric) bool
 
 	Describe(ch chan<- *prometheus.Desc)
 }
 
-// Returns an instance of StatusExporter configured with the modem's
-// address, admin username and password.
-func NewStatusExporter(addr, username, password string) StatusExporter {
-	var (
-		dsLabelNames = []string{"channel", "lock_status", "modulation", "channel_id", "frequency"}
-		usLabelNames = []string{"channel", "lock_status", "channel_type", "channel_id", "frequency"}
-	)
+// NewStatusExporter returns an instance of StatusExporter configured with the
+// modem's address, admin username and password, scraped using driver.
+func NewStatusExporter(device, addr, username, password string, driver ModemDriver, logger log.Logger, httpConfig HTTPClientConfig) (StatusExporter, error) {
+	httpClient, err := httpClientFromConfig(httpConfig)
+	if err != nil {
+		return StatusExporter{}, err
+	}
 
 	return StatusExporter{
 		// Modem access details.
-		url:             "http://" + addr + "/DocsisStatus.asp",
+		device:          device,
+		url:             httpConfig.Scheme + "://" + addr + driver.StatusURL(),
 		authHeaderValue: "Basic " + basicAuth(username, password),
-
-		// Collection metrics.
+		driver:          driver,
+		logger:          log.With(logger, "device", device),
+		httpClient:      httpClient,
+
+		// Collection metrics. Each StatusExporter is scoped to a single
+		// device, so the device label is constant rather than a vector
+		// dimension; without it, two devices registered against the same
+		// collector would both report the same unlabeled series and
+		// Gather() would reject the duplicate.
 		totalScrapes: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,
-			Name:      "status_scrapes_total",
-			Help:      "Total number of scrapes of the modem status page.",
+			Namespace:   namespace,
+			Name:        "status_scrapes_total",
+			Help:        "Total number of scrapes of the modem status page.",
+			ConstLabels: prometheus.Labels{"device": device},
 		}),
 		scrapeErrors: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,
-			Name:      "status_scrape_errors_total",
-			Help:      "Total number of failed scrapes of the modem status page.",
+			Namespace:   namespace,
+			Name:        "status_scrape_errors_total",
+			Help:        "Total number of failed scrapes of the modem status page.",
+			ConstLabels: prometheus.Labels{"device": device},
 		}),
+	}, nil
+}
+
+// Collect runs our scrape loop, returning each Prometheus metric and
+// reporting whether the scrape succeeded.
+func (e *StatusExporter) Collect(ch chan<- prometheus.Metric) bool {
+	e.totalScrapes.Inc()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	success := true
+	if err := e.scrape(ch); err != nil {
+		level.Error(e.logger).Log("msg", "status scrape failed", "url", e.url, "err", err)
+		e.scrapeErrors.Inc()
+		success = false
+	}
+
+	e.totalScrapes.Collect(ch)
+	e.scrapeErrors.Collect(ch)
+
+	return success
+}
+
+func (e *StatusExporter) scrape(ch chan<- prometheus.Metric) error {
+	req, err := http.NewRequest("GET", e.url, nil)
+	if err != nil {
+		return errors.Wrap(err, "building status request")
+	}
+	req.Header.Add("Authorization", e.authHeaderValue)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "requesting status page")
+	}
+	defer resp.Body.Close()
 
-		// Downstream metrics.
-		dsChannelSNR: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "downstream_channel", "snr_db"),
-			"Downstream channel signal to noise ratio in dB.",
-			dsLabelNames, nil,
-		),
-		dsChannelPower: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "downstream_channel", "power_dbmv"),
-			"Downstream channel power in dBmV.",
-			dsLabelNames, nil,
-		),
-		dsChannelCorrectableErrs: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "downstream_channel", "correctable_errors_total"),
-			"Downstream channel correctable errors.",
-			dsLabelNames, nil,
-		),
-		dsChannelUncorrectableErrs: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "downstream_channel", "uncorrectable_errors_total"),
-			"Downstream channel uncorrectable errors.",
-			dsLabelNames, nil,
-		),
-
-		// Upstream metrics.
-		usChannelPower: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "upstream_channel", "power_dbmv"),
-			"Upstream channel power in dBmV.",
-			usLabelNames, nil,
-		),
-		usChannelSymbolRate: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "upstream_channel", "symbol_rate"),
-			"Upstream channel symbol rate per second",
-			usLabelNames, nil,
-		),
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("status page returned %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
+
+	level.Debug(e.logger).Log("msg", "fetched status page", "url", e.url, "status_code", resp.StatusCode)
+
+	return e.driver.ParseStatus(e.logger, e.device, resp.Body, ch)
 }
 
-func NewStatusExporterFactory(addr, username, password string, modemType string) CMExporter {
+// Describe returns Prometheus metric descriptions for the exporter metrics.
+func (e *StatusExporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- e.totalScrapes.Desc()
+	ch <- e.scrapeErrors.Desc()
+	e.driver.Describe(ch)
+}
 
-	switch modemType {
-	case "CM600":
-		return &CM600StatusExporter{StatusExporter: NewStatusExporter(addr, username, password)}
-	case "CM1000":
-		return &CM1000StatusExporter{StatusExporter: NewStatusExporter(addr, username, password)}
-	default:
-		log.Println("The modem type" + modemType + " is not known. Defaulting to CM600")
-		return &CM600StatusExporter{StatusExporter: NewStatusExporter(addr, username, password)}
+// NewStatusExporterFactory looks up the registered ModemDriver for modemType
+// and returns a StatusExporter that scrapes addr with it.
+func NewStatusExporterFactory(device, addr, username, password, modemType string, logger log.Logger, httpConfig HTTPClientConfig) (CMExporter, error) {
+	driver, err := driverByName(modemType)
+	if err != nil {
+		return nil, err
 	}
 
+	exporter, err := NewStatusExporter(device, addr, username, password, driver, logger, httpConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &exporter, nil
+}
+
+// newDeviceTargets builds one deviceTarget per configured modem.
+func newDeviceTargets(config *Config, logger log.Logger) ([]*deviceTarget, error) {
+	sink, err := ParseEventSink(config.Events.Sink)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]*deviceTarget, 0, len(config.Devices))
+	for _, d := range config.Devices {
+		status, err := NewStatusExporterFactory(d.Name, d.Address, d.Username, d.Password, d.Model, logger, config.HTTPClient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "device %q", d.Name)
+		}
+
+		events, err := NewEventExporterFactory(d.Name, d.Address, d.Username, d.Password, d.Model, sink, config.Events.Path, logger, config.HTTPClient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "device %q", d.Name)
+		}
+
+		targets = append(targets, &deviceTarget{name: d.Name, status: status, events: events})
+	}
+	return targets, nil
 }
 
 func main() {
 	var (
 		configFile  = flag.String("config.file", "netgear_cm_exporter.yml", "Path to configuration file.")
+		webConfig   = flag.String("web.config.file", "", "Path to a file enabling TLS and/or basic auth on the exporter's own HTTP server.")
 		showVersion = flag.Bool("version", false, "Print version information.")
+		logLevel    = flag.String("log.level", "info", "Logging level: debug, info, warn or error.")
+		logFormat   = flag.String("log.format", "logfmt", "Logging format: logfmt or json.")
 	)
 	flag.Parse()
 
 	if *showVersion {
 		fmt.Printf("netgear_cm_exporter version=%s revision=%s branch=%s buildUser=%s buildDate=%s\n",
 			version, revision, branch, buildUser, buildDate)
-		os.Exit(0)
+		return
 	}
 
-	config, err := NewConfigFromFile(*configFile)
+	logger, err := newLogger(*logLevel, *logFormat)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	//		exporter := NewEventExporterFactory( config.Modem.Address, config.Modem.Username, config.Modem.Password )
+	config, err := NewConfigFromFile(*configFile)
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to load config", "err", err)
+		os.Exit(1)
+	}
 
-	exporter := NewStatusExporterFactory(config.Modem.Address, config.Modem.Username, config.Modem.Password, config.Modem.Model)
+	targets, err := newDeviceTargets(config, logger)
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to build device targets", "err", err)
+		os.Exit(1)
+	}
 
-	prometheus.MustRegister(exporter)
+	prometheus.MustRegister(newDeviceCollector(targets))
 
-	http.Handle(config.Telemetry.MetricsPath, promhttp.Handler())
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.Handle(config.Telemetry.MetricsPath, promhttp.Handler())
+	mux.HandleFunc("/probe", newProbeHandler(config, logger))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, config.Telemetry.MetricsPath, http.StatusMovedPermanently)
 	})
 
-	log.Printf("exporter listening on %s", config.Telemetry.ListenAddress)
-	if err := http.ListenAndServe(config.Telemetry.ListenAddress, nil); err != nil {
-		log.Fatalf("failed to start netgear exporter: %s", err)
+	server := &http.Server{Addr: config.Telemetry.ListenAddress, Handler: mux}
+
+	level.Info(logger).Log("msg", "exporter listening", "address", config.Telemetry.ListenAddress)
+	if err := web.ListenAndServe(server, *webConfig, logger); err != nil {
+		level.Error(logger).Log("msg", "failed to start netgear exporter", "err", err)
+		os.Exit(1)
 	}
 }